@@ -4,6 +4,9 @@ import (
     "context"
     "fmt"
     "log"
+    "os"
+    "os/signal"
+    "syscall"
     "time"
 
     "sensorfuzz/scheduler/pkg/scheduler"
@@ -11,7 +14,7 @@ import (
 
 func main() {
     ctx := context.Background()
-    queue := scheduler.NewRedisQueue("redis://localhost:6379/0", "sensor-fuzz-tasks")
+    queue := scheduler.NewRedisQueue(scheduler.RedisClientOpt{Addr: "redis://localhost:6379/0"}, "sensor-fuzz-tasks")
     worker := scheduler.NewWorker(queue, func(t scheduler.Task) scheduler.Result {
         // Placeholder handler: simulate processing time
         time.Sleep(10 * time.Millisecond)
@@ -27,6 +30,13 @@ func main() {
         }
     }
 
-    time.Sleep(1 * time.Second)
-    worker.Stop()
+    // Run until interrupted, then drain in-flight work before exiting so
+    // a Ctrl-C mid-fuzz-run doesn't lose a payload mid-transmission.
+    sig := make(chan os.Signal, 1)
+    signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+    <-sig
+
+    shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+    defer cancel()
+    worker.Shutdown(shutdownCtx)
 }
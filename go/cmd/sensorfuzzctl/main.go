@@ -0,0 +1,65 @@
+// Command sensorfuzzctl is an operator CLI for inspecting a running
+// sensor-fuzz campaign's scheduler state.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"sensorfuzz/scheduler/pkg/scheduler"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(1)
+	}
+
+	switch os.Args[1] {
+	case "ps":
+		runPS(os.Args[2:])
+	default:
+		usage()
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: sensorfuzzctl <command> [flags]")
+	fmt.Fprintln(os.Stderr, "commands:")
+	fmt.Fprintln(os.Stderr, "  ps    list running servers and their in-flight tasks")
+}
+
+func runPS(args []string) {
+	fs := flag.NewFlagSet("ps", flag.ExitOnError)
+	addr := fs.String("redis", "redis://localhost:6379/0", "redis connection URL")
+	namespace := fs.String("namespace", "sensor-fuzz-tasks", "queue namespace")
+	fs.Parse(args)
+
+	ctx := context.Background()
+	queue := scheduler.NewRedisQueue(scheduler.RedisClientOpt{Addr: *addr}, *namespace)
+
+	servers, err := queue.ListServers(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "sensorfuzzctl: list servers: %v\n", err)
+		os.Exit(1)
+	}
+	if len(servers) == 0 {
+		fmt.Println("no running servers")
+		return
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tHOST\tPID\tSTARTED\tCONCURRENCY\tQUEUES\tACTIVE")
+	for _, s := range servers {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%d\t%s\t%d\n",
+			s.ID, s.Host, s.PID, s.StartedAt.Format(time.RFC3339),
+			s.Concurrency, strings.Join(s.Queues, ","), len(s.ActiveTasks))
+	}
+	tw.Flush()
+}
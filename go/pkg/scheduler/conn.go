@@ -0,0 +1,77 @@
+package scheduler
+
+import "github.com/go-redis/redis/v8"
+
+// RedisConnOpt is implemented by the concrete connection option types
+// below and produces the go-redis client a RedisQueue uses: a single
+// instance, a Sentinel-fronted failover group, or a Redis Cluster.
+type RedisConnOpt interface {
+	MakeRedisClient() redis.UniversalClient
+}
+
+// RedisClientOpt connects to a single, non-clustered Redis instance.
+// Addr may be a redis:// URL or a bare host:port.
+type RedisClientOpt struct {
+	Addr     string
+	Password string
+	DB       int
+}
+
+// MakeRedisClient implements RedisConnOpt.
+func (o RedisClientOpt) MakeRedisClient() redis.UniversalClient {
+	opts, err := redis.ParseURL(o.Addr)
+	if err != nil {
+		// Fall back to treating Addr as a bare host:port, which is the
+		// common case for local development.
+		opts = &redis.Options{Addr: o.Addr}
+	}
+	if o.Password != "" {
+		opts.Password = o.Password
+	}
+	if o.DB != 0 {
+		opts.DB = o.DB
+	}
+	return redis.NewClient(opts)
+}
+
+// RedisFailoverOpt connects to a Redis deployment fronted by Sentinel,
+// following whichever node Sentinel currently elects master of
+// MasterName.
+type RedisFailoverOpt struct {
+	MasterName    string
+	SentinelAddrs []string
+	Password      string
+	DB            int
+}
+
+// MakeRedisClient implements RedisConnOpt.
+func (o RedisFailoverOpt) MakeRedisClient() redis.UniversalClient {
+	return redis.NewFailoverClient(&redis.FailoverOptions{
+		MasterName:    o.MasterName,
+		SentinelAddrs: o.SentinelAddrs,
+		Password:      o.Password,
+		DB:            o.DB,
+	})
+}
+
+// RedisClusterOpt connects to a Redis Cluster. Every key the scheduler
+// generates for a given queue is hash-tagged with that queue's name
+// (e.g. "sensorfuzz:{mqtt}:pending"), so every multi-key Lua script in
+// this package — which only ever operates on one queue's pending,
+// scheduled and retry keys at a time — stays within a single cluster
+// hash slot. Heartbeat and corpus state use global, untagged keys:
+// heartbeat writes go through non-transactional pipelining rather than
+// MULTI/EXEC, and corpus updates are single-key commands and scripts,
+// so neither trips CROSSSLOT.
+type RedisClusterOpt struct {
+	Addrs    []string
+	Password string
+}
+
+// MakeRedisClient implements RedisConnOpt.
+func (o RedisClusterOpt) MakeRedisClient() redis.UniversalClient {
+	return redis.NewClusterClient(&redis.ClusterOptions{
+		Addrs:    o.Addrs,
+		Password: o.Password,
+	})
+}
@@ -0,0 +1,304 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// defaultBitmapSize is the default size of the coalesced coverage
+	// bitmap, in bytes. Must be a power of two.
+	defaultBitmapSize = 64 * 1024
+
+	coverageKey = "sensorfuzz:coverage"
+	corpusKey   = "sensorfuzz:corpus"
+
+	// coverageChunkSize bounds how many bitmap bytes a single
+	// updateCoverageChunkScript call touches, the same way batchSize caps
+	// a single moveDueTasksScript call: a full defaultBitmapSize map is
+	// classified client-side in Go, then merged into Redis in bounded
+	// chunks so one Result can't block Redis scanning 64KiB in Lua.
+	coverageChunkSize = 4096
+)
+
+// updateCoverageChunkScript ORs a pre-classified chunk (ARGV[2], starting
+// at byte offset ARGV[1]) of AFL-style bucket bitmasks into the global
+// coverage map (KEYS[1]), and reports whether any (index, bucket) pair in
+// the chunk was not already set globally.
+var updateCoverageChunkScript = redis.NewScript(`
+local key = KEYS[1]
+local offset = tonumber(ARGV[1])
+local classified = ARGV[2]
+
+local global = redis.call('GETRANGE', key, offset, offset + #classified - 1)
+if #global < #classified then
+    global = global .. string.rep('\0', #classified - #global)
+end
+
+local interesting = 0
+local out = {}
+for i = 1, #classified do
+    local class = string.byte(classified, i)
+    local seen = string.byte(global, i)
+    if class ~= 0 and bit.band(class, bit.bnot(seen)) ~= 0 then
+        interesting = 1
+    end
+    out[i] = string.char(bit.bor(seen, class))
+end
+
+redis.call('SETRANGE', key, offset, table.concat(out))
+return interesting
+`)
+
+// classifyHitCount buckets a single coverage byte into one of AFL's 8
+// log2 hit-count ranges, matching afl-fuzz's count_class_lookup8 table.
+func classifyHitCount(count byte) byte {
+	switch {
+	case count == 0:
+		return 0
+	case count == 1:
+		return 1
+	case count == 2:
+		return 2
+	case count == 3:
+		return 4
+	case count <= 7:
+		return 8
+	case count <= 15:
+		return 16
+	case count <= 31:
+		return 32
+	case count <= 127:
+		return 64
+	default:
+		return 128
+	}
+}
+
+// CorpusManager maintains a coalesced, global AFL-style coverage bitmap
+// and a corpus of interesting payloads in Redis, evolving a fuzz
+// campaign toward inputs that increase sensor-protocol coverage rather
+// than blindly replaying seeds.
+type CorpusManager struct {
+	queue      *RedisQueue
+	bitmapSize int
+}
+
+// CorpusOption configures a CorpusManager constructed by
+// NewCorpusManager.
+type CorpusOption func(*CorpusManager)
+
+// WithBitmapSize overrides the coverage bitmap size, in bytes. Must
+// match the size handlers report in Result.Coverage. Defaults to 64KiB.
+func WithBitmapSize(n int) CorpusOption {
+	return func(c *CorpusManager) { c.bitmapSize = n }
+}
+
+// NewCorpusManager returns a CorpusManager backed by queue's Redis
+// connection. If opts configure a bitmap size that isn't a positive
+// power of two, it falls back to defaultBitmapSize.
+func NewCorpusManager(queue *RedisQueue, opts ...CorpusOption) *CorpusManager {
+	c := &CorpusManager{queue: queue, bitmapSize: defaultBitmapSize}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if !isPowerOfTwo(c.bitmapSize) {
+		log.Printf("scheduler: corpus bitmap size %d is not a positive power of two, falling back to %d", c.bitmapSize, defaultBitmapSize)
+		c.bitmapSize = defaultBitmapSize
+	}
+	return c
+}
+
+// isPowerOfTwo reports whether n is a positive power of two.
+func isPowerOfTwo(n int) bool {
+	return n > 0 && n&(n-1) == 0
+}
+
+// CorpusEntry is a payload archived in the corpus because it was found
+// to increase global coverage.
+type CorpusEntry struct {
+	Input       []byte
+	Size        int
+	ProducedAt  time.Time
+	EnergyScore float64
+
+	// Queue and Protocol are copied from the Task that produced this
+	// entry, so EnqueueNext can replay it as a Task targeting the same
+	// queue it came from.
+	Queue    string
+	Protocol string
+}
+
+// Process folds a Task's Result into the coverage map and corpus. If
+// result.Coverage hits any (index, bucket) pair not previously seen
+// globally, every entry in result.NewInputs is archived in the corpus
+// and re-enqueued as a fresh Task derived from t. Ready queues are plain
+// FIFO lists (see Dequeue), so a mutated Task is enqueued behind whatever
+// else is already waiting on t.Queue, not ahead of it. execDuration is
+// how long t took to process, used to favor faster inputs in
+// EnergyScore.
+func (c *CorpusManager) Process(ctx context.Context, t Task, result Result, execDuration time.Duration) error {
+	if len(result.Coverage) == 0 {
+		return nil
+	}
+
+	interesting, err := c.recordCoverage(ctx, result.Coverage)
+	if err != nil {
+		return fmt.Errorf("scheduler: record coverage: %w", err)
+	}
+	if !interesting {
+		return nil
+	}
+
+	for i, input := range result.NewInputs {
+		if err := c.addEntry(ctx, t, input, execDuration); err != nil {
+			return err
+		}
+
+		mutated := t
+		mutated.ID = fmt.Sprintf("%s-mut-%d", t.ID, i)
+		mutated.Payload = map[string]string{"seed": base64.StdEncoding.EncodeToString(input)}
+		mutated.Retry = 0
+		mutated.Retried = 0
+		if err := c.queue.Enqueue(ctx, mutated); err != nil {
+			return fmt.Errorf("scheduler: enqueue mutated input: %w", err)
+		}
+	}
+	return nil
+}
+
+// recordCoverage classifies coverage into AFL-style bucket bitmasks in
+// Go, then merges the result into the global coverage map in
+// coverageChunkSize pieces, returning whether any chunk hit a bucket not
+// previously seen globally. coverage must be exactly c.bitmapSize bytes,
+// matching whatever size handlers were told to report.
+func (c *CorpusManager) recordCoverage(ctx context.Context, coverage []byte) (bool, error) {
+	if len(coverage) != c.bitmapSize {
+		return false, fmt.Errorf("scheduler: coverage is %d bytes, want configured bitmap size %d", len(coverage), c.bitmapSize)
+	}
+
+	classified := make([]byte, len(coverage))
+	for i, n := range coverage {
+		classified[i] = classifyHitCount(n)
+	}
+
+	interesting := false
+	for offset := 0; offset < len(classified); offset += coverageChunkSize {
+		end := offset + coverageChunkSize
+		if end > len(classified) {
+			end = len(classified)
+		}
+		res, err := updateCoverageChunkScript.Run(ctx, c.queue.client, []string{coverageKey}, offset, classified[offset:end]).Int()
+		if err != nil {
+			return false, err
+		}
+		if res == 1 {
+			interesting = true
+		}
+	}
+	return interesting, nil
+}
+
+// addEntry archives input in the corpus ZSET, scored by EnergyScore so
+// the scheduler's next-input selection favors smaller and faster inputs
+// over blindly replaying the whole corpus. How recently an entry proved
+// productive is not part of its score.
+// source is the Task that produced input, recorded on the entry so
+// EnqueueNext can later replay it against the same queue and protocol.
+func (c *CorpusManager) addEntry(ctx context.Context, source Task, input []byte, execDuration time.Duration) error {
+	entry := CorpusEntry{
+		Input:      input,
+		Size:       len(input),
+		ProducedAt: time.Now(),
+		Queue:      source.Queue,
+		Protocol:   source.Protocol,
+	}
+	entry.EnergyScore = energyScore(entry.Size, execDuration)
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("scheduler: marshal corpus entry: %w", err)
+	}
+	return c.queue.client.ZAdd(ctx, corpusKey, &redis.Z{Score: entry.EnergyScore, Member: data}).Err()
+}
+
+// energyScore favors smaller and faster inputs, per AFL's favored-seed
+// heuristic. The score depends only on size and execDuration; it does
+// not change with the entry's age or with later replays.
+func energyScore(size int, execDuration time.Duration) float64 {
+	sizeFactor := 1.0 / float64(size+1)
+	speedFactor := 1.0 / (execDuration.Seconds() + 0.001)
+	return sizeFactor * speedFactor
+}
+
+// NextInput returns the corpus entry with the highest energy score —
+// the seed the scheduler should prioritize mutating next — or ok=false
+// if the corpus is empty.
+func (c *CorpusManager) NextInput(ctx context.Context) (entry CorpusEntry, ok bool, err error) {
+	res, err := c.queue.client.ZRevRangeWithScores(ctx, corpusKey, 0, 0).Result()
+	if err != nil {
+		return CorpusEntry{}, false, err
+	}
+	if len(res) == 0 {
+		return CorpusEntry{}, false, nil
+	}
+
+	member, _ := res[0].Member.(string)
+	if err := json.Unmarshal([]byte(member), &entry); err != nil {
+		return CorpusEntry{}, false, fmt.Errorf("scheduler: unmarshal corpus entry: %w", err)
+	}
+	return entry, true, nil
+}
+
+// EnqueueNext claims the corpus's highest-energy seed and re-enqueues it
+// as a fresh Task on the queue it originally came from, returning
+// ok=false if the corpus is empty. Worker calls this whenever its ready
+// queues are idle, so EnergyScore's size/speed bias actually reaches the
+// scheduler instead of sitting unused: the best seed gets replayed,
+// mutated, and re-scored instead of the campaign stalling on an empty
+// BRPOP.
+//
+// It claims via ZPOPMAX rather than NextInput's plain peek, then
+// immediately re-adds the entry with its original score. Only a caller
+// whose ZPOPMAX lands between another caller's claim and restore pops a
+// different member (the next-highest); any call after the restore claims
+// the same top entry again, so repeated calls, in this process or a
+// peer, keep replaying the highest-energy seed.
+func (c *CorpusManager) EnqueueNext(ctx context.Context) (ok bool, err error) {
+	res, err := c.queue.client.ZPopMax(ctx, corpusKey, 1).Result()
+	if err != nil {
+		return false, err
+	}
+	if len(res) == 0 {
+		return false, nil
+	}
+	claimed := res[0]
+
+	data, _ := claimed.Member.(string)
+	var entry CorpusEntry
+	unmarshalErr := json.Unmarshal([]byte(data), &entry)
+
+	if err := c.queue.client.ZAdd(ctx, corpusKey, &redis.Z{Score: claimed.Score, Member: data}).Err(); err != nil {
+		return false, fmt.Errorf("scheduler: restore corpus entry after claim: %w", err)
+	}
+	if unmarshalErr != nil {
+		return false, fmt.Errorf("scheduler: unmarshal corpus entry: %w", unmarshalErr)
+	}
+
+	t := Task{
+		ID:       fmt.Sprintf("corpus-%d", time.Now().UnixNano()),
+		Protocol: entry.Protocol,
+		Queue:    entry.Queue,
+		Payload:  map[string]string{"seed": base64.StdEncoding.EncodeToString(entry.Input)},
+	}
+	if err := c.queue.Enqueue(ctx, t); err != nil {
+		return false, fmt.Errorf("scheduler: enqueue corpus seed: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,113 @@
+package scheduler
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestClassifyHitCount(t *testing.T) {
+	cases := []struct {
+		count byte
+		want  byte
+	}{
+		{0, 0},
+		{1, 1},
+		{2, 2},
+		{3, 4},
+		{7, 8},
+		{15, 16},
+		{31, 32},
+		{127, 64},
+		{128, 128},
+		{255, 128},
+	}
+	for _, c := range cases {
+		if got := classifyHitCount(c.count); got != c.want {
+			t.Errorf("classifyHitCount(%d) = %d, want %d", c.count, got, c.want)
+		}
+	}
+}
+
+func TestEnergyScoreFavorsSmallerFasterInputs(t *testing.T) {
+	small := energyScore(10, 10*time.Millisecond)
+	large := energyScore(1000, 10*time.Millisecond)
+	if small <= large {
+		t.Errorf("energyScore(10, ...) = %v, want > energyScore(1000, ...) = %v", small, large)
+	}
+
+	fast := energyScore(10, 1*time.Millisecond)
+	slow := energyScore(10, 1*time.Second)
+	if fast <= slow {
+		t.Errorf("energyScore(..., 1ms) = %v, want > energyScore(..., 1s) = %v", fast, slow)
+	}
+}
+
+func TestEnergyScoreFinite(t *testing.T) {
+	if s := energyScore(0, 0); math.IsInf(s, 0) || math.IsNaN(s) {
+		t.Errorf("energyScore(0, 0) = %v, want a finite value", s)
+	}
+}
+
+func TestNewCorpusManagerRejectsNonPowerOfTwoBitmapSize(t *testing.T) {
+	q, _ := newTestQueue(t)
+
+	c := NewCorpusManager(q, WithBitmapSize(100))
+	if c.bitmapSize != defaultBitmapSize {
+		t.Errorf("bitmapSize = %d, want fallback to defaultBitmapSize %d", c.bitmapSize, defaultBitmapSize)
+	}
+
+	c = NewCorpusManager(q, WithBitmapSize(8192))
+	if c.bitmapSize != 8192 {
+		t.Errorf("bitmapSize = %d, want the configured power-of-two 8192", c.bitmapSize)
+	}
+}
+
+func TestRecordCoverageRejectsSizeMismatch(t *testing.T) {
+	ctx := context.Background()
+	q, _ := newTestQueue(t)
+	c := NewCorpusManager(q, WithBitmapSize(16))
+
+	if _, err := c.recordCoverage(ctx, make([]byte, 8)); err == nil {
+		t.Fatal("recordCoverage with mismatched length: want error, got nil")
+	}
+}
+
+func TestEnqueueNextReplaysHighestEnergySeedOnItsOriginQueue(t *testing.T) {
+	ctx := context.Background()
+	q, _ := newTestQueue(t)
+	c := NewCorpusManager(q, WithBitmapSize(16))
+
+	if ok, err := c.EnqueueNext(ctx); err != nil || ok {
+		t.Fatalf("EnqueueNext on empty corpus = (%v, %v), want (false, nil)", ok, err)
+	}
+
+	// Seed the corpus directly (bypassing Process/recordCoverage, whose
+	// Lua script needs a real Redis build, not miniredis) to isolate
+	// EnqueueNext's own behavior: pull the top entry and replay it on its
+	// originating queue and protocol.
+	source := Task{ID: "seed1", Queue: "mqtt", Protocol: "mqtt-v5"}
+	if err := c.addEntry(ctx, source, []byte("interesting-input"), 5*time.Millisecond); err != nil {
+		t.Fatalf("addEntry: %v", err)
+	}
+
+	ok, err := c.EnqueueNext(ctx)
+	if err != nil || !ok {
+		t.Fatalf("EnqueueNext after addEntry = (%v, %v), want (true, nil)", ok, err)
+	}
+
+	got, err := q.Dequeue(ctx, []string{"mqtt"}, time.Second)
+	if err != nil {
+		t.Fatalf("Dequeue: %v", err)
+	}
+	if got == nil {
+		t.Fatal("Dequeue: got nil task, want the replayed seed")
+	}
+	if got.Protocol != source.Protocol {
+		t.Errorf("replayed task Protocol = %q, want %q", got.Protocol, source.Protocol)
+	}
+	if got.Payload["seed"] == "" {
+		t.Error("replayed task missing seed payload")
+	}
+}
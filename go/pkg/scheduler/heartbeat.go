@@ -0,0 +1,358 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	heartbeatInterval = 5 * time.Second
+	// heartbeatTTL is how long a server/worker entry survives in Redis
+	// without a fresh heartbeat before it's considered dead.
+	heartbeatTTL = 3 * heartbeatInterval
+
+	serversZKey    = "sensorfuzz:servers"
+	serversInfoKey = "sensorfuzz:servers:info"
+	workersZKey    = "sensorfuzz:workers"
+	workersInfoKey = "sensorfuzz:workers:info"
+)
+
+// ServerInfo describes a running Worker process, for introspection via
+// ListServers.
+type ServerInfo struct {
+	ID          string
+	Host        string
+	PID         int
+	StartedAt   time.Time
+	Concurrency int
+	Queues      []string
+	ActiveTasks []string
+}
+
+// WorkerInfo describes a single in-flight task slot, for introspection
+// via ListWorkers.
+type WorkerInfo struct {
+	ServerID  string
+	TaskID    string
+	Queue     string
+	Protocol  string
+	StartedAt time.Time
+}
+
+// heartbeater periodically publishes a Worker's liveness and current
+// workload to Redis so operators running dozens of workers across hosts
+// can see who's alive and what payload each is currently sending.
+type heartbeater struct {
+	queue  *RedisQueue
+	server ServerInfo
+
+	mu     sync.Mutex
+	active map[string]WorkerInfo
+}
+
+func newHeartbeater(queue *RedisQueue, queues []string, concurrency int) *heartbeater {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return &heartbeater{
+		queue: queue,
+		server: ServerInfo{
+			ID:          fmt.Sprintf("%s:%d", host, os.Getpid()),
+			Host:        host,
+			PID:         os.Getpid(),
+			StartedAt:   time.Now(),
+			Concurrency: concurrency,
+			Queues:      queues,
+		},
+		active: make(map[string]WorkerInfo),
+	}
+}
+
+// trackStart records t as in-flight, both in memory (for the next
+// heartbeat snapshot) and in a per-server Redis set, so that if this
+// worker crashes a peer can recover t on its next heartbeat sweep.
+func (h *heartbeater) trackStart(ctx context.Context, t Task) {
+	h.mu.Lock()
+	h.active[t.ID] = WorkerInfo{
+		ServerID:  h.server.ID,
+		TaskID:    t.ID,
+		Queue:     t.Queue,
+		Protocol:  t.Protocol,
+		StartedAt: time.Now(),
+	}
+	h.mu.Unlock()
+
+	data, err := json.Marshal(t)
+	if err != nil {
+		return
+	}
+	if err := h.queue.client.SAdd(ctx, inFlightKey(h.server.ID), data).Err(); err != nil {
+		log.Printf("scheduler: track in-flight task %s: %v", t.ID, err)
+	}
+}
+
+// trackDone clears t from both the in-memory snapshot and the per-server
+// in-flight set once its handler has returned.
+func (h *heartbeater) trackDone(ctx context.Context, t Task) {
+	h.mu.Lock()
+	delete(h.active, t.ID)
+	h.mu.Unlock()
+
+	data, err := json.Marshal(t)
+	if err != nil {
+		return
+	}
+	if err := h.queue.client.SRem(ctx, inFlightKey(h.server.ID), data).Err(); err != nil {
+		log.Printf("scheduler: untrack in-flight task %s: %v", t.ID, err)
+	}
+}
+
+// drainInFlight requeues every task still in this server's in-flight
+// set back onto the head of its source queue. Called on graceful
+// shutdown once the handler has returned or ShutdownTimeout elapsed.
+func (h *heartbeater) drainInFlight(ctx context.Context) {
+	requeueOrphaned(ctx, h.queue, h.server.ID)
+}
+
+func (h *heartbeater) snapshot() (ServerInfo, []WorkerInfo) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	server := h.server
+	workers := make([]WorkerInfo, 0, len(h.active))
+	for _, w := range h.active {
+		server.ActiveTasks = append(server.ActiveTasks, w.TaskID)
+		workers = append(workers, w)
+	}
+	return server, workers
+}
+
+// run beats immediately, then every heartbeatInterval, until stop is
+// closed or ctx is done. On clean shutdown it removes this server's
+// entries so `sensorfuzzctl ps` doesn't show it lingering until TTL.
+func (h *heartbeater) run(ctx context.Context, stop <-chan struct{}) {
+	h.beat(ctx)
+
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			h.clear(ctx)
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.beat(ctx)
+		}
+	}
+}
+
+func (h *heartbeater) beat(ctx context.Context) error {
+	server, workers := h.snapshot()
+	now := time.Now()
+	expiry := float64(now.Add(heartbeatTTL).UnixNano())
+
+	serverData, err := json.Marshal(server)
+	if err != nil {
+		return fmt.Errorf("scheduler: marshal server info: %w", err)
+	}
+
+	// A plain Pipeline, not TxPipeline: these commands touch several
+	// global, non-hash-tagged keys, so a cluster-mode MULTI/EXEC would
+	// fail with CROSSSLOT. Batching the round trip without a
+	// transaction is all the atomicity this heartbeat needs.
+	pipe := h.queue.client.Pipeline()
+	pipe.HSet(ctx, serversInfoKey, server.ID, serverData)
+	pipe.ZAdd(ctx, serversZKey, &redis.Z{Score: expiry, Member: server.ID})
+
+	for _, w := range workers {
+		id := w.ServerID + ":" + w.TaskID
+		data, err := json.Marshal(w)
+		if err != nil {
+			continue
+		}
+		pipe.HSet(ctx, workersInfoKey, id, data)
+		pipe.ZAdd(ctx, workersZKey, &redis.Z{Score: expiry, Member: id})
+	}
+
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		return err
+	}
+	return reapExpired(ctx, h.queue, now)
+}
+
+// clear best-effort removes this server's own entries from Redis so a
+// clean shutdown doesn't leave it visible until heartbeatTTL elapses.
+func (h *heartbeater) clear(ctx context.Context) {
+	server, workers := h.snapshot()
+
+	pipe := h.queue.client.Pipeline() // see beat's comment on why not TxPipeline
+	pipe.ZRem(ctx, serversZKey, server.ID)
+	pipe.HDel(ctx, serversInfoKey, server.ID)
+	for _, w := range workers {
+		id := w.ServerID + ":" + w.TaskID
+		pipe.ZRem(ctx, workersZKey, id)
+		pipe.HDel(ctx, workersInfoKey, id)
+	}
+	pipe.Exec(ctx)
+}
+
+// inFlightKey names the Redis set holding JSON-encoded Tasks currently
+// being processed by the server identified by serverID.
+func inFlightKey(serverID string) string {
+	return fmt.Sprintf("sensorfuzz:inflight:%s", serverID)
+}
+
+// reapExpired drops server/worker entries whose heartbeat has lapsed
+// from both the expiry ZSET and the info hash. Any task still in an
+// expired server's in-flight set is requeued, recovering work from a
+// worker that crashed without running its shutdown drain.
+func reapExpired(ctx context.Context, queue *RedisQueue, now time.Time) error {
+	cutoff := fmt.Sprintf("%d", now.UnixNano())
+	client := queue.client
+
+	expiredServers, err := client.ZRangeByScore(ctx, serversZKey, &redis.ZRangeBy{Min: "-inf", Max: cutoff}).Result()
+	if err == nil && len(expiredServers) > 0 {
+		// Plain Pipeline: serversZKey and serversInfoKey are global,
+		// untagged keys, so a cluster-mode MULTI/EXEC would CROSSSLOT.
+		pipe := client.Pipeline()
+		pipe.ZRemRangeByScore(ctx, serversZKey, "-inf", cutoff)
+		pipe.HDel(ctx, serversInfoKey, expiredServers...)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+		for _, serverID := range expiredServers {
+			requeueOrphaned(ctx, queue, serverID)
+		}
+	}
+
+	expiredWorkers, err := client.ZRangeByScore(ctx, workersZKey, &redis.ZRangeBy{Min: "-inf", Max: cutoff}).Result()
+	if err == nil && len(expiredWorkers) > 0 {
+		pipe := client.Pipeline()
+		pipe.ZRemRangeByScore(ctx, workersZKey, "-inf", cutoff)
+		pipe.HDel(ctx, workersInfoKey, expiredWorkers...)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// requeueOrphaned moves every task left in serverID's in-flight set back
+// onto the head of its source queue, via LPUSH so it is retried ahead of
+// tasks that haven't started yet. Each member is claimed via its own
+// atomic SREM before being pushed: SREM only reports 1 for whichever
+// peer runs it first, so when every live worker's heartbeat sweep
+// observes the same expired server and races to recover it, exactly one
+// of them wins the claim and LPUSHes — the rest see 0 and skip. This is
+// deliberately two single-key commands rather than one multi-key script:
+// inFlightKey is a global, untagged key while the destination ready
+// queue is hash-tagged per queue name, so a single EVAL spanning both
+// would CROSSSLOT under Redis Cluster.
+func requeueOrphaned(ctx context.Context, queue *RedisQueue, serverID string) {
+	key := inFlightKey(serverID)
+	members, err := queue.client.SMembers(ctx, key).Result()
+	if err != nil || len(members) == 0 {
+		return
+	}
+
+	for _, data := range members {
+		var t Task
+		if err := json.Unmarshal([]byte(data), &t); err != nil {
+			continue
+		}
+		removed, err := queue.client.SRem(ctx, key, data).Result()
+		if err != nil {
+			log.Printf("scheduler: claim orphaned task %s from dead server %s: %v", t.ID, serverID, err)
+			continue
+		}
+		if removed == 0 {
+			// A peer already claimed this task; nothing left to do.
+			continue
+		}
+		if err := queue.client.LPush(ctx, queue.readyKey(t.Queue), data).Err(); err != nil {
+			log.Printf("scheduler: requeue orphaned task %s from dead server %s: %v", t.ID, serverID, err)
+		}
+	}
+}
+
+// ListServers returns every Worker server currently reporting a live
+// heartbeat. This and ListWorkers are RedisQueue methods rather than
+// package-level functions, following ListDead/RequeueDead in retry.go:
+// they need a live connection to query, and RedisQueue is already this
+// package's handle to one.
+func (q *RedisQueue) ListServers(ctx context.Context) ([]ServerInfo, error) {
+	ids, err := q.client.ZRangeByScore(ctx, serversZKey, &redis.ZRangeBy{
+		Min: fmt.Sprintf("%d", time.Now().UnixNano()),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	raw, err := q.client.HMGet(ctx, serversInfoKey, ids...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	servers := make([]ServerInfo, 0, len(raw))
+	for _, v := range raw {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		var info ServerInfo
+		if err := json.Unmarshal([]byte(s), &info); err != nil {
+			return nil, fmt.Errorf("scheduler: unmarshal server info: %w", err)
+		}
+		servers = append(servers, info)
+	}
+	return servers, nil
+}
+
+// ListWorkers returns every in-flight task slot currently reporting a
+// live heartbeat, across all servers.
+func (q *RedisQueue) ListWorkers(ctx context.Context) ([]WorkerInfo, error) {
+	ids, err := q.client.ZRangeByScore(ctx, workersZKey, &redis.ZRangeBy{
+		Min: fmt.Sprintf("%d", time.Now().UnixNano()),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	raw, err := q.client.HMGet(ctx, workersInfoKey, ids...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	workers := make([]WorkerInfo, 0, len(raw))
+	for _, v := range raw {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		var info WorkerInfo
+		if err := json.Unmarshal([]byte(s), &info); err != nil {
+			return nil, fmt.Errorf("scheduler: unmarshal worker info: %w", err)
+		}
+		workers = append(workers, info)
+	}
+	return workers, nil
+}
@@ -0,0 +1,195 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	defaultPollInterval = 5 * time.Second
+	defaultBatchSize    = 100
+	// defaultQueueName is used for a Task whose Queue field is unset.
+	defaultQueueName = "default"
+)
+
+// moveDueTasksScript atomically promotes every member of a due-task ZSET
+// (KEYS[1]) whose score is <= ARGV[1] into a ready list (KEYS[2]), capped
+// at ARGV[2] members per call so a long ZSET can't block Redis for long.
+var moveDueTasksScript = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, payload in ipairs(due) do
+    redis.call('ZREM', KEYS[1], payload)
+    redis.call('RPUSH', KEYS[2], payload)
+end
+return #due
+`)
+
+// RedisQueue namespaces ready, scheduled, retry and dead task state in
+// Redis under one or more named queues (e.g. "mqtt", "coap",
+// "ble-critical"), so a single campaign can co-schedule fast liveness
+// probes alongside slow deep-mutation payloads without one starving the
+// other.
+type RedisQueue struct {
+	client    redis.UniversalClient
+	namespace string
+
+	pollInterval time.Duration
+	batchSize    int64
+}
+
+// Option configures a RedisQueue constructed by NewRedisQueue.
+type Option func(*RedisQueue)
+
+// WithPollInterval overrides how often the scheduled/retry poller checks
+// for due tasks. Defaults to 5s.
+func WithPollInterval(d time.Duration) Option {
+	return func(q *RedisQueue) { q.pollInterval = d }
+}
+
+// WithBatchSize caps how many due tasks are moved from a scheduled or
+// retry set into its ready queue per poll tick. Defaults to 100.
+func WithBatchSize(n int64) Option {
+	return func(q *RedisQueue) { q.batchSize = n }
+}
+
+// NewRedisQueue connects to Redis via connOpt and returns a queue that
+// namespaces all keys it manages under namespace.
+func NewRedisQueue(connOpt RedisConnOpt, namespace string, opts ...Option) *RedisQueue {
+	q := &RedisQueue{
+		client:       connOpt.MakeRedisClient(),
+		namespace:    namespace,
+		pollInterval: defaultPollInterval,
+		batchSize:    defaultBatchSize,
+	}
+	for _, opt := range opts {
+		opt(q)
+	}
+	return q
+}
+
+// NewQueueOnCluster is a convenience wrapper around
+// NewRedisQueue(RedisClusterOpt{...}, namespace, opts...) for running
+// against a Redis Cluster.
+func NewQueueOnCluster(addrs []string, password, namespace string, opts ...Option) *RedisQueue {
+	return NewRedisQueue(RedisClusterOpt{Addrs: addrs, Password: password}, namespace, opts...)
+}
+
+// readyKey returns queueName's ready list key, hash-tagged so that it
+// and scheduledKey/retryKey/deadKey for the same queue always land on
+// the same Redis Cluster slot.
+func (q *RedisQueue) readyKey(queueName string) string {
+	return q.queueKey(queueName, "pending")
+}
+
+func (q *RedisQueue) scheduledKey(queueName string) string {
+	return q.queueKey(queueName, "scheduled")
+}
+
+func (q *RedisQueue) retryKey(queueName string) string {
+	return q.queueKey(queueName, "retry")
+}
+
+func (q *RedisQueue) deadKey(queueName string) string {
+	return q.queueKey(queueName, "dead")
+}
+
+// queueKey builds a namespace:{queueName}:suffix key. The {queueName}
+// hash tag ensures every key built for the same queue hashes to the
+// same Redis Cluster slot.
+func (q *RedisQueue) queueKey(queueName, suffix string) string {
+	if queueName == "" {
+		queueName = defaultQueueName
+	}
+	return fmt.Sprintf("%s:{%s}:%s", q.namespace, queueName, suffix)
+}
+
+// Enqueue pushes t onto the ready queue named by t.Queue (or the default
+// queue, if unset) for immediate dequeue.
+func (q *RedisQueue) Enqueue(ctx context.Context, t Task) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("scheduler: marshal task: %w", err)
+	}
+	return q.client.RPush(ctx, q.readyKey(t.Queue), data).Err()
+}
+
+// EnqueueAt schedules t to become ready at executeAt. It is stored in
+// t.Queue's scheduled sorted set until a poller promotes it.
+func (q *RedisQueue) EnqueueAt(ctx context.Context, t Task, executeAt time.Time) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("scheduler: marshal task: %w", err)
+	}
+	return q.client.ZAdd(ctx, q.scheduledKey(t.Queue), &redis.Z{
+		Score:  float64(executeAt.UnixNano()),
+		Member: data,
+	}).Err()
+}
+
+// EnqueueIn schedules t to become ready after delay has elapsed.
+func (q *RedisQueue) EnqueueIn(ctx context.Context, t Task, delay time.Duration) error {
+	return q.EnqueueAt(ctx, t, time.Now().Add(delay))
+}
+
+// Dequeue blocks for up to timeout waiting for a ready task, checking
+// queueOrder's queues in the order given (the first queue with a ready
+// task wins). It returns nil if none arrived in time.
+func (q *RedisQueue) Dequeue(ctx context.Context, queueOrder []string, timeout time.Duration) (*Task, error) {
+	keys := make([]string, len(queueOrder))
+	for i, name := range queueOrder {
+		keys[i] = q.readyKey(name)
+	}
+
+	res, err := q.client.BLPop(ctx, timeout, keys...).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var t Task
+	if err := json.Unmarshal([]byte(res[1]), &t); err != nil {
+		return nil, fmt.Errorf("scheduler: unmarshal task: %w", err)
+	}
+	return &t, nil
+}
+
+// runDuePoller periodically moves, for each queue in queueNames, due
+// scheduled and retry tasks into that queue's ready list, until stop is
+// closed or ctx is done.
+func (q *RedisQueue) runDuePoller(ctx context.Context, stop <-chan struct{}, queueNames []string) {
+	ticker := time.NewTicker(q.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, name := range queueNames {
+				if err := q.promoteDueTasks(ctx, q.scheduledKey(name), q.readyKey(name)); err != nil {
+					log.Printf("scheduler: scheduled poll failed for queue %q: %v", name, err)
+				}
+				if err := q.promoteDueTasks(ctx, q.retryKey(name), q.readyKey(name)); err != nil {
+					log.Printf("scheduler: retry poll failed for queue %q: %v", name, err)
+				}
+			}
+		}
+	}
+}
+
+// promoteDueTasks runs moveDueTasksScript once, moving at most
+// q.batchSize tasks whose execution time has passed from zsetKey into
+// destKey.
+func (q *RedisQueue) promoteDueTasks(ctx context.Context, zsetKey, destKey string) error {
+	now := time.Now().UnixNano()
+	return moveDueTasksScript.Run(ctx, q.client, []string{zsetKey, destKey}, now, q.batchSize).Err()
+}
@@ -0,0 +1,138 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	defaultMaxRetry    = 25
+	defaultBackoffBase = 1 * time.Second
+	defaultBackoffCap  = 10 * time.Minute
+	// maxBackoffShift bounds the 2^n term so large Retried counts can't
+	// overflow the time.Duration computation below.
+	maxBackoffShift = 30
+)
+
+// backoff returns the delay before retry attempt n (0-indexed), computed
+// as min(cap, base * 2^n) plus up to 1s of jitter so retries from a
+// batch of failed tasks don't all land on the same ZSET score.
+func backoff(n int) time.Duration {
+	if n > maxBackoffShift {
+		n = maxBackoffShift
+	}
+	d := defaultBackoffBase * time.Duration(1<<uint(n))
+	if d <= 0 || d > defaultBackoffCap {
+		d = defaultBackoffCap
+	}
+	return d + time.Duration(rand.Int63n(int64(time.Second)))
+}
+
+// scheduleRetry records a failed attempt on t and either re-enqueues it
+// into the retry ZSET with an exponential backoff delay, or archives it
+// to the dead set once it has exhausted Task.Retry attempts.
+func (q *RedisQueue) scheduleRetry(ctx context.Context, t Task, result Result) error {
+	t.Retried++
+	t.ErrorMsg = result.Details
+	t.LastFailedAt = time.Now()
+
+	maxRetry := t.Retry
+	if maxRetry == 0 {
+		maxRetry = defaultMaxRetry
+	}
+	if t.Retried >= maxRetry {
+		return q.moveToDead(ctx, t)
+	}
+
+	data, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("scheduler: marshal task: %w", err)
+	}
+	executeAt := time.Now().Add(backoff(t.Retried - 1))
+	return q.client.ZAdd(ctx, q.retryKey(t.Queue), &redis.Z{
+		Score:  float64(executeAt.UnixNano()),
+		Member: data,
+	}).Err()
+}
+
+// moveToDead archives t in its queue's dead ZSET, scored by the time it
+// died, so operators can inspect and optionally requeue it later.
+func (q *RedisQueue) moveToDead(ctx context.Context, t Task) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("scheduler: marshal task: %w", err)
+	}
+	return q.client.ZAdd(ctx, q.deadKey(t.Queue), &redis.Z{
+		Score:  float64(time.Now().UnixNano()),
+		Member: data,
+	}).Err()
+}
+
+// DeadTask pairs an archived Task with the time it was moved to the dead
+// set.
+type DeadTask struct {
+	Task   Task
+	DiedAt time.Time
+}
+
+// ListDead returns every task currently archived in queueName's dead
+// set, for operators inspecting failed fuzz cases.
+func (q *RedisQueue) ListDead(ctx context.Context, queueName string) ([]DeadTask, error) {
+	members, err := q.client.ZRangeWithScores(ctx, q.deadKey(queueName), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	dead := make([]DeadTask, 0, len(members))
+	for _, m := range members {
+		payload, ok := m.Member.(string)
+		if !ok {
+			return nil, fmt.Errorf("scheduler: unexpected dead member type %T", m.Member)
+		}
+		var t Task
+		if err := json.Unmarshal([]byte(payload), &t); err != nil {
+			return nil, fmt.Errorf("scheduler: unmarshal dead task: %w", err)
+		}
+		dead = append(dead, DeadTask{Task: t, DiedAt: time.Unix(0, int64(m.Score))})
+	}
+	return dead, nil
+}
+
+// RequeueDead moves the dead task with the given ID back onto
+// queueName's ready queue, resetting its retry count.
+func (q *RedisQueue) RequeueDead(ctx context.Context, queueName, id string) error {
+	dead, err := q.ListDead(ctx, queueName)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range dead {
+		if d.Task.ID != id {
+			continue
+		}
+
+		oldData, err := json.Marshal(d.Task)
+		if err != nil {
+			return fmt.Errorf("scheduler: marshal task: %w", err)
+		}
+
+		d.Task.Retried = 0
+		d.Task.ErrorMsg = ""
+		newData, err := json.Marshal(d.Task)
+		if err != nil {
+			return fmt.Errorf("scheduler: marshal task: %w", err)
+		}
+
+		pipe := q.client.TxPipeline()
+		pipe.ZRem(ctx, q.deadKey(queueName), oldData)
+		pipe.RPush(ctx, q.readyKey(queueName), newData)
+		_, err = pipe.Exec(ctx)
+		return err
+	}
+	return fmt.Errorf("scheduler: no dead task with id %q", id)
+}
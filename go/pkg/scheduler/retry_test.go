@@ -0,0 +1,106 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestBackoff(t *testing.T) {
+	cases := []struct {
+		n    int
+		want time.Duration
+	}{
+		{n: 0, want: defaultBackoffBase},
+		{n: 1, want: 2 * defaultBackoffBase},
+		{n: 3, want: 8 * defaultBackoffBase},
+		{n: maxBackoffShift + 5, want: defaultBackoffCap},
+	}
+	for _, c := range cases {
+		d := backoff(c.n)
+		if d < c.want || d > c.want+time.Second {
+			t.Errorf("backoff(%d) = %v, want in [%v, %v]", c.n, d, c.want, c.want+time.Second)
+		}
+	}
+}
+
+func TestBackoffCapsAtDefaultBackoffCap(t *testing.T) {
+	d := backoff(60)
+	if d < defaultBackoffCap || d > defaultBackoffCap+time.Second {
+		t.Errorf("backoff(60) = %v, want capped at %v", d, defaultBackoffCap)
+	}
+}
+
+func newTestQueue(t *testing.T) (*RedisQueue, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	q := NewRedisQueue(RedisClientOpt{Addr: mr.Addr()}, "test")
+	return q, mr
+}
+
+func TestScheduleRetryThenDeadThenRequeueDead(t *testing.T) {
+	ctx := context.Background()
+	q, _ := newTestQueue(t)
+
+	task := Task{ID: "t1", Queue: "mqtt", Retry: 2}
+	result := Result{TaskID: task.ID, Status: "error", Details: "boom"}
+
+	// First failure: retried, not yet dead.
+	if err := q.scheduleRetry(ctx, task, result); err != nil {
+		t.Fatalf("scheduleRetry (1st): %v", err)
+	}
+	dead, err := q.ListDead(ctx, task.Queue)
+	if err != nil {
+		t.Fatalf("ListDead: %v", err)
+	}
+	if len(dead) != 0 {
+		t.Fatalf("ListDead after 1st failure = %d entries, want 0", len(dead))
+	}
+
+	// Second failure reaches Retry, so it's archived to the dead set.
+	task.Retried = 1
+	if err := q.scheduleRetry(ctx, task, result); err != nil {
+		t.Fatalf("scheduleRetry (2nd): %v", err)
+	}
+	dead, err = q.ListDead(ctx, task.Queue)
+	if err != nil {
+		t.Fatalf("ListDead: %v", err)
+	}
+	if len(dead) != 1 || dead[0].Task.ID != task.ID {
+		t.Fatalf("ListDead after 2nd failure = %+v, want one entry for %q", dead, task.ID)
+	}
+
+	// RequeueDead moves it back onto the ready queue and resets Retried.
+	if err := q.RequeueDead(ctx, task.Queue, task.ID); err != nil {
+		t.Fatalf("RequeueDead: %v", err)
+	}
+	dead, err = q.ListDead(ctx, task.Queue)
+	if err != nil {
+		t.Fatalf("ListDead after requeue: %v", err)
+	}
+	if len(dead) != 0 {
+		t.Fatalf("ListDead after RequeueDead = %d entries, want 0", len(dead))
+	}
+
+	requeued, err := q.Dequeue(ctx, []string{task.Queue}, time.Second)
+	if err != nil {
+		t.Fatalf("Dequeue: %v", err)
+	}
+	if requeued == nil || requeued.ID != task.ID {
+		t.Fatalf("Dequeue after RequeueDead = %+v, want task %q", requeued, task.ID)
+	}
+	if requeued.Retried != 0 {
+		t.Errorf("requeued task Retried = %d, want 0", requeued.Retried)
+	}
+}
+
+func TestRequeueDeadUnknownID(t *testing.T) {
+	ctx := context.Background()
+	q, _ := newTestQueue(t)
+
+	if err := q.RequeueDead(ctx, "mqtt", "does-not-exist"); err == nil {
+		t.Fatal("RequeueDead with unknown id: want error, got nil")
+	}
+}
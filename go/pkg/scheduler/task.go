@@ -0,0 +1,49 @@
+// Package scheduler implements task queueing and worker execution for
+// the sensor fuzzing framework, backed by Redis.
+package scheduler
+
+import "time"
+
+// Task represents a single fuzzing payload to be delivered to a sensor
+// protocol handler.
+type Task struct {
+	ID       string
+	Protocol string
+	Payload  map[string]string
+	Priority int
+	// Queue is the named queue this task is enqueued onto (e.g. "mqtt",
+	// "coap", "ble-critical"). Empty means the default queue.
+	Queue string
+
+	// Retry is the maximum number of attempts allowed for this task,
+	// counting its first run: once Retried reaches Retry the task is
+	// moved to the dead letter set instead of being retried, so Retry 2
+	// allows one retry. Zero means the package default (25) applies.
+	Retry int
+	// Retried counts this task's failed attempts so far; while its Nth
+	// retry runs, Retried is N.
+	Retried int
+	// ErrorMsg holds the Result.Details from the most recent failed
+	// attempt.
+	ErrorMsg string
+	// LastFailedAt is when the most recent failed attempt completed.
+	LastFailedAt time.Time
+}
+
+// Result is returned by a task handler once it has finished processing
+// a Task.
+type Result struct {
+	TaskID  string
+	Status  string
+	Details string
+
+	// Coverage is an AFL-style 8-bit hit counter bitmap recorded while
+	// the handler delivered this Task, sized to CorpusManager's
+	// configured bitmap size. Nil means the handler did not report
+	// coverage.
+	Coverage []byte
+	// NewInputs are payload mutations the handler produced while
+	// processing this Task, to be fed back into the corpus if Coverage
+	// shows they hit new program state.
+	NewInputs [][]byte
+}
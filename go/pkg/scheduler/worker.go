@@ -0,0 +1,320 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// dequeueTimeout bounds how long each Worker poll waits on an empty
+// queue before checking for a Stop signal again.
+const dequeueTimeout = 1 * time.Second
+
+// defaultShutdownTimeout bounds how long Shutdown waits for an in-flight
+// task's handler to return before forcibly requeuing it.
+const defaultShutdownTimeout = 30 * time.Second
+
+// HandlerFunc processes a single Task and reports the outcome.
+type HandlerFunc func(Task) Result
+
+// WorkerOption configures a Worker constructed by NewWorker.
+type WorkerOption func(*Worker)
+
+// WithQueueConfig sets the named queues a Worker serves, mapped to their
+// relative weight. Defaults to {"default": 1}.
+func WithQueueConfig(cfg map[string]int) WorkerOption {
+	return func(w *Worker) { w.queueConfig = cfg }
+}
+
+// WithStrictPriority makes the Worker always check higher-weighted
+// queues before lower-weighted ones, rather than weighting dequeue order
+// probabilistically.
+func WithStrictPriority(strict bool) WorkerOption {
+	return func(w *Worker) { w.strictPriority = strict }
+}
+
+// WithShutdownTimeout overrides how long Shutdown waits for the
+// in-flight task's handler to return before forcibly requeuing it.
+// Defaults to 30s.
+func WithShutdownTimeout(d time.Duration) WorkerOption {
+	return func(w *Worker) { w.shutdownTimeout = d }
+}
+
+// WithCorpusManager feeds every handled Task's Result into corpus, so
+// coverage-increasing inputs are archived and re-enqueued on the source
+// Task's queue. They are enqueued like any other Task; no priority boost
+// is applied to them.
+func WithCorpusManager(corpus *CorpusManager) WorkerOption {
+	return func(w *Worker) { w.corpus = corpus }
+}
+
+// WithConcurrency sets how many dequeue loops the Worker runs in
+// parallel, each independently pulling and handling one Task at a time.
+// Values below 1 are treated as 1. Defaults to 1.
+func WithConcurrency(n int) WorkerOption {
+	return func(w *Worker) {
+		if n < 1 {
+			n = 1
+		}
+		w.concurrency = n
+	}
+}
+
+// Worker pulls Tasks off a RedisQueue's named queues and dispatches them
+// to a HandlerFunc.
+type Worker struct {
+	queue   *RedisQueue
+	handler HandlerFunc
+
+	queueConfig     map[string]int
+	strictPriority  bool
+	shutdownTimeout time.Duration
+	concurrency     int
+
+	heartbeat *heartbeater
+	corpus    *CorpusManager
+	// corpusReplayMu avoids every one of this Worker's run() goroutines
+	// making its own redundant EnqueueNext round trip when they all idle
+	// at once under WithConcurrency(N). It is a local efficiency guard
+	// only: it does not coordinate with peer processes, and since
+	// EnqueueNext restores each entry right after claiming it, idle peers
+	// can replay the same corpus entry.
+	corpusReplayMu sync.Mutex
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+
+	mu        sync.Mutex
+	running   map[string]bool
+	abandoned map[string]bool
+}
+
+// NewWorker returns a Worker that invokes handler for every Task pulled
+// from queue.
+func NewWorker(queue *RedisQueue, handler HandlerFunc, opts ...WorkerOption) *Worker {
+	w := &Worker{
+		queue:           queue,
+		handler:         handler,
+		queueConfig:     map[string]int{defaultQueueName: 1},
+		shutdownTimeout: defaultShutdownTimeout,
+		concurrency:     1,
+		stop:            make(chan struct{}),
+		done:            make(chan struct{}),
+		running:         make(map[string]bool),
+		abandoned:       make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+func (w *Worker) queueNames() []string {
+	names := make([]string, 0, len(w.queueConfig))
+	for name := range w.queueConfig {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Start launches the Worker's Concurrency dequeue loops, the background
+// poller that promotes due scheduled and retry tasks into their ready
+// queues, and the heartbeater that publishes this worker's liveness and
+// workload so it shows up in `sensorfuzzctl ps`.
+func (w *Worker) Start(ctx context.Context) {
+	w.heartbeat = newHeartbeater(w.queue, w.queueNames(), w.concurrency)
+
+	go w.queue.runDuePoller(ctx, w.stop, w.queueNames())
+	go w.heartbeat.run(ctx, w.stop)
+
+	var wg sync.WaitGroup
+	wg.Add(w.concurrency)
+	for i := 0; i < w.concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			w.run(ctx)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(w.done)
+	}()
+}
+
+func (w *Worker) run(ctx context.Context) {
+	for {
+		select {
+		case <-w.stop:
+			return
+		default:
+		}
+
+		task, err := w.queue.Dequeue(ctx, w.dequeueOrder(), dequeueTimeout)
+		if err != nil {
+			continue
+		}
+		if task == nil {
+			// Ready queues are empty: replay the corpus's best-known
+			// seed instead of idling, so EnergyScore actually drives
+			// what gets fuzzed next. TryLock so only one idle goroutine
+			// does this per tick instead of every concurrent one.
+			if w.corpus != nil && w.corpusReplayMu.TryLock() {
+				if _, err := w.corpus.EnqueueNext(ctx); err != nil {
+					log.Printf("scheduler: corpus seed replay failed: %v", err)
+				}
+				w.corpusReplayMu.Unlock()
+			}
+			continue
+		}
+
+		w.mu.Lock()
+		w.running[task.ID] = true
+		w.mu.Unlock()
+
+		w.heartbeat.trackStart(ctx, *task)
+		start := time.Now()
+		result := w.process(*task)
+		execDuration := time.Since(start)
+
+		w.mu.Lock()
+		delete(w.running, task.ID)
+		abandoned := w.abandoned[task.ID]
+		delete(w.abandoned, task.ID)
+		w.mu.Unlock()
+
+		if abandoned {
+			// Shutdown's timeout already requeued this task via
+			// drainInFlight while the handler was still running; applying
+			// the now-stale result would process it a second time.
+			log.Printf("scheduler: discarding result for task %s, already requeued after shutdown timeout", task.ID)
+			continue
+		}
+
+		w.heartbeat.trackDone(ctx, *task)
+
+		if w.corpus != nil {
+			if err := w.corpus.Process(ctx, *task, result, execDuration); err != nil {
+				log.Printf("scheduler: corpus feedback failed for task %s: %v", task.ID, err)
+			}
+		}
+
+		if result.Status == "error" {
+			if err := w.queue.scheduleRetry(ctx, *task, result); err != nil {
+				log.Printf("scheduler: retry scheduling failed for task %s: %v", task.ID, err)
+			}
+		}
+	}
+}
+
+// process invokes the handler, converting a panic into an error Result
+// so a single misbehaving handler can't take the worker loop down.
+func (w *Worker) process(t Task) (result Result) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = Result{TaskID: t.ID, Status: "error", Details: fmt.Sprintf("panic: %v", r)}
+		}
+	}()
+	return w.handler(t)
+}
+
+// dequeueOrder returns the queue names in the order this poll's BRPOP
+// should check them: a fixed descending-weight order under strict
+// priority, or a fresh weighted shuffle (asynq's approach) so
+// higher-weighted queues are checked more often without starving lower-
+// weighted ones.
+func (w *Worker) dequeueOrder() []string {
+	if w.strictPriority {
+		return strictQueueOrder(w.queueConfig)
+	}
+	return weightedQueueOrder(w.queueConfig)
+}
+
+// strictQueueOrder sorts queue names by descending weight, breaking ties
+// alphabetically for determinism.
+func strictQueueOrder(cfg map[string]int) []string {
+	names := make([]string, 0, len(cfg))
+	for name := range cfg {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if cfg[names[i]] != cfg[names[j]] {
+			return cfg[names[i]] > cfg[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// weightedQueueOrder builds a pool containing each queue name repeated
+// weight times, shuffles it, then dedupes it preserving first occurrence
+// to produce a per-poll priority order biased toward higher-weighted
+// queues. A non-positive weight still gets a single slot per pool, the
+// same as strictQueueOrder includes it at the back of the fixed order,
+// so a misconfigured weight can't permanently starve a queue.
+func weightedQueueOrder(cfg map[string]int) []string {
+	pool := make([]string, 0, len(cfg))
+	for name, weight := range cfg {
+		if weight < 1 {
+			weight = 1
+		}
+		for i := 0; i < weight; i++ {
+			pool = append(pool, name)
+		}
+	}
+	rand.Shuffle(len(pool), func(i, j int) { pool[i], pool[j] = pool[j], pool[i] })
+
+	seen := make(map[string]bool, len(cfg))
+	order := make([]string, 0, len(cfg))
+	for _, name := range pool {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		order = append(order, name)
+	}
+	return order
+}
+
+// Stop triggers a graceful shutdown using the worker's configured
+// ShutdownTimeout and blocks until it completes. It is equivalent to
+// Shutdown(context.Background()).
+func (w *Worker) Stop() {
+	w.Shutdown(context.Background())
+}
+
+// Shutdown stops the worker from pulling new tasks, then waits for its
+// current handler (if any) to return, up to ShutdownTimeout or until ctx
+// is done, whichever comes first. Once it returns or gives up waiting,
+// it requeues any task still in-flight back to the head of its source
+// queue, so a killed worker doesn't lose a fuzz payload mid-transmission.
+//
+// If ShutdownTimeout elapses with a handler still running, that task is
+// requeued at-least-once: the run loop marks it abandoned so its
+// eventual result is discarded rather than also completing it, but the
+// handler itself is not interrupted and keeps running until it returns.
+func (w *Worker) Shutdown(ctx context.Context) {
+	w.stopOnce.Do(func() { close(w.stop) })
+
+	timer := time.NewTimer(w.shutdownTimeout)
+	defer timer.Stop()
+	select {
+	case <-w.done:
+	case <-timer.C:
+		log.Printf("scheduler: shutdown timeout elapsed with a task still in flight")
+		w.mu.Lock()
+		for id := range w.running {
+			w.abandoned[id] = true
+		}
+		w.mu.Unlock()
+	case <-ctx.Done():
+	}
+
+	if w.heartbeat != nil {
+		w.heartbeat.drainInFlight(context.Background())
+	}
+}
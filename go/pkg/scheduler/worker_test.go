@@ -0,0 +1,69 @@
+package scheduler
+
+import "testing"
+
+func TestStrictQueueOrder(t *testing.T) {
+	cfg := map[string]int{"low": 1, "high": 10, "mid": 5}
+	got := strictQueueOrder(cfg)
+	want := []string{"high", "mid", "low"}
+	if !equalStrings(got, want) {
+		t.Errorf("strictQueueOrder(%v) = %v, want %v", cfg, got, want)
+	}
+}
+
+func TestStrictQueueOrderIncludesNonPositiveWeight(t *testing.T) {
+	cfg := map[string]int{"starved": 0, "busy": 3}
+	got := strictQueueOrder(cfg)
+	want := []string{"busy", "starved"}
+	if !equalStrings(got, want) {
+		t.Errorf("strictQueueOrder(%v) = %v, want %v", cfg, got, want)
+	}
+}
+
+func TestWeightedQueueOrderIncludesEveryQueue(t *testing.T) {
+	cfg := map[string]int{"a": 1, "b": 2, "c": 3}
+	for i := 0; i < 50; i++ {
+		got := weightedQueueOrder(cfg)
+		if len(got) != len(cfg) {
+			t.Fatalf("weightedQueueOrder(%v) = %v, want %d distinct names", cfg, got, len(cfg))
+		}
+		for name := range cfg {
+			if !contains(got, name) {
+				t.Fatalf("weightedQueueOrder(%v) = %v, missing %q", cfg, got, name)
+			}
+		}
+	}
+}
+
+func TestWeightedQueueOrderNonPositiveWeightNotStarved(t *testing.T) {
+	cfg := map[string]int{"starved": 0, "busy": 5}
+	for i := 0; i < 50; i++ {
+		got := weightedQueueOrder(cfg)
+		if !contains(got, "starved") {
+			t.Fatalf("weightedQueueOrder(%v) = %v, weight<=0 queue must still appear", cfg, got)
+		}
+	}
+}
+
+// equalStrings compares two slices element-by-element, in order: strict
+// mode's output order is part of its contract.
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}